@@ -0,0 +1,93 @@
+package unbound
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// DNSError represents a DNS lookup error, mirroring the surface of
+// net.DNSError so that code which already handles the latter needs no new
+// error-handling path to work with this package.
+type DNSError struct {
+	Err         string // description of the error
+	Name        string // name looked up
+	Server      string // server used
+	IsTimeout   bool   // if true, the lookup timed out
+	IsTemporary bool   // if true, the error is temporary; a retry may succeed
+	IsNotFound  bool   // if true, the name does not exist
+	IsBogus     bool   // if true, the answer failed DNSSEC validation
+}
+
+func (e *DNSError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	s := "lookup " + e.Name
+	if e.Server != "" {
+		s += " on " + e.Server
+	}
+	s += ": " + e.Err
+	return s
+}
+
+// Timeout reports whether the DNS error is known to report that a lookup
+// timed out, for compatibility with the net.Error interface.
+func (e *DNSError) Timeout() bool { return e.IsTimeout }
+
+// Temporary reports whether the DNS error is known to be temporary, for
+// compatibility with the net.Error interface.
+func (e *DNSError) Temporary() bool { return e.IsTimeout || e.IsTemporary }
+
+// errEmptyHost is returned, with name filled in by the caller, when a
+// Lookup* function is asked to resolve the empty string. libunbound would
+// otherwise happily issue a query for the empty label.
+func errEmptyHost(name string) *DNSError {
+	return &DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// newDNSError builds a *DNSError describing a failed or empty lookup of
+// name. err is whatever Resolve/ResolveAsync returned, if anything; r is
+// the accompanying *Result, which may be nil when err is non-nil and may be
+// non-nil but empty (HaveData false) when err is nil.
+//
+// The raw libunbound return code (UB_NOERROR, UB_SERVFAIL, UB_NOMEM, ...) is
+// not available at this layer, since Resolve/ResolveAsync already format it
+// into err via ub_strerror; newDNSError does its best to recover a
+// classification from that string and from the Result's Rcode/NxDomain/
+// HaveData flags.
+func newDNSError(err error, r *Result, name string) *DNSError {
+	e := &DNSError{Name: name}
+
+	if err != nil {
+		e.Err = err.Error()
+		switch msg := strings.ToLower(e.Err); {
+		case strings.Contains(msg, "timed out"), strings.Contains(msg, "timeout"):
+			e.IsTimeout = true
+		case strings.Contains(msg, "servfail"), strings.Contains(msg, "out of memory"), strings.Contains(msg, "no memory"), strings.Contains(msg, "socket"):
+			e.IsTemporary = true
+		}
+		return e
+	}
+
+	if r != nil {
+		switch {
+		case r.NxDomain, !r.HaveData && r.Rcode == dns.RcodeSuccess:
+			e.IsNotFound = true
+			e.Err = "no such host"
+		case r.Rcode == dns.RcodeServerFailure:
+			e.IsTemporary = true
+			e.Err = "server failure"
+		default:
+			// Some other Rcode (Refused, FormatError, NotImplemented, ...):
+			// the server rejected or couldn't handle the query, but that is
+			// not the same claim as "this name doesn't exist", so it must
+			// not set IsNotFound.
+			e.Err = "server misbehaving"
+		}
+		return e
+	}
+
+	e.Err = "unknown error"
+	return e
+}
@@ -0,0 +1,70 @@
+package unbound
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestNewDNSErrorFromErrString(t *testing.T) {
+	cases := []struct {
+		errStr        string
+		wantTimeout   bool
+		wantTemporary bool
+	}{
+		{"resolve timed out", true, false},
+		{"context deadline exceeded: timeout", true, false},
+		{"SERVFAIL", false, true},
+		{"out of memory", false, true},
+		{"no memory available", false, true},
+		{"socket error", false, true},
+		{"some other failure", false, false},
+	}
+	for _, c := range cases {
+		e := newDNSError(errors.New(c.errStr), nil, "example.com")
+		if e.IsTimeout != c.wantTimeout || e.IsTemporary != c.wantTemporary {
+			t.Errorf("newDNSError(%q): IsTimeout=%v IsTemporary=%v, want IsTimeout=%v IsTemporary=%v",
+				c.errStr, e.IsTimeout, e.IsTemporary, c.wantTimeout, c.wantTemporary)
+		}
+		if e.Name != "example.com" {
+			t.Errorf("newDNSError(%q): Name = %q, want %q", c.errStr, e.Name, "example.com")
+		}
+	}
+}
+
+func TestNewDNSErrorFromResult(t *testing.T) {
+	cases := []struct {
+		name          string
+		r             *Result
+		wantNotFound  bool
+		wantTemporary bool
+	}{
+		{"nxdomain", &Result{NxDomain: true}, true, false},
+		{"no data, success rcode", &Result{HaveData: false, Rcode: dns.RcodeSuccess}, true, false},
+		{"server failure", &Result{Rcode: dns.RcodeServerFailure}, false, true},
+		{"refused must not read as not-found", &Result{HaveData: false, Rcode: dns.RcodeRefused}, false, false},
+		{"have data but otherwise unclassified", &Result{HaveData: true, Rcode: dns.RcodeFormatError}, false, false},
+	}
+	for _, c := range cases {
+		e := newDNSError(nil, c.r, "example.com")
+		if e.IsNotFound != c.wantNotFound || e.IsTemporary != c.wantTemporary {
+			t.Errorf("%s: IsNotFound=%v IsTemporary=%v, want IsNotFound=%v IsTemporary=%v",
+				c.name, e.IsNotFound, e.IsTemporary, c.wantNotFound, c.wantTemporary)
+		}
+	}
+}
+
+func TestNewDNSErrorNoErrNoResult(t *testing.T) {
+	e := newDNSError(nil, nil, "example.com")
+	if e.Err != "unknown error" {
+		t.Fatalf("newDNSError(nil, nil, ...): Err = %q, want %q", e.Err, "unknown error")
+	}
+}
+
+func TestErrEmptyHost(t *testing.T) {
+	e := errEmptyHost("")
+	if !e.IsNotFound {
+		t.Fatalf("errEmptyHost: expected IsNotFound, got %+v", e)
+	}
+}
@@ -2,7 +2,10 @@ package unbound
 
 import (
 	"github.com/miekg/dns"
+	"math/rand"
 	"net"
+	"sync"
+	"time"
 )
 
 // These are function are a re-implementation of the net.Lookup* ones
@@ -15,13 +18,19 @@ func (u *Unbound) LookupAddr(addr string) (name []string, err error) {
 	if err != nil {
 		return nil, err
 	}
-	r, err := u.Resolve(reverse, dns.TypePTR, dns.ClassINET)
+	r, err := u.resolveSF(reverse, dns.TypePTR, dns.ClassINET)
 	if err != nil {
-		return nil, err
+		return nil, newDNSError(err, r, addr)
 	}
 	for _, rr := range r.Rr {
 		name = append(name, rr.(*dns.RR_PTR).Ptr)
 	}
+	if len(name) == 0 {
+		return nil, newDNSError(nil, r, addr)
+	}
+	if e := u.checkBogus(r, addr); e != nil {
+		return nil, e
+	}
 	return
 }
 
@@ -30,14 +39,26 @@ func (u *Unbound) LookupAddr(addr string) (name []string, err error) {
 // LookupIP directly; both take care of resolving the canonical name as
 // part of the lookup. 
 func (u *Unbound) LookupCNAME(name string) (cname string, err error) {
-	r, err := u.Resolve(name, dns.TypeA, dns.ClassINET)
+	if name == "" {
+		return "", errEmptyHost(name)
+	}
+	r, err := u.resolveSF(name, dns.TypeA, dns.ClassINET)
 	// TODO(mg): if nothing found try AAAA?
-	return r.CanonName, err
+	if err != nil {
+		return "", newDNSError(err, r, name)
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return "", e
+	}
+	return r.CanonName, nil
 }
 
 // LookupHost looks up the given host using Unbound. It returns
 // an array of that host's addresses.
 func (u *Unbound) LookupHost(host string) (addrs []string, err error) {
+	if host == "" {
+		return nil, errEmptyHost(host)
+	}
 	ipaddrs, err := u.LookupIP(host)
 	if err != nil {
 		return nil, err
@@ -52,39 +73,65 @@ func (u *Unbound) LookupHost(host string) (addrs []string, err error) {
 // that host's IPv4 and IPv6 addresses.
 // The A and AAAA lookups are performed in parallel.
 func (u *Unbound) LookupIP(host string) (addrs []net.IP, err error) {
-	ca := make(chan *Result)
-	caaaa := make(chan *Result)
+	if host == "" {
+		return nil, errEmptyHost(host)
+	}
+
+	ca := make(chan *lookupResult, 1)
+	caaaa := make(chan *lookupResult, 1)
 
-	u.ResolveAsync(host, dns.TypeA, dns.ClassINET, ca, lookupHelper)
-	u.ResolveAsync(host, dns.TypeAAAA, dns.ClassINET, caaaa, lookupHelper)
+	u.resolveAsyncSF(host, dns.TypeA, dns.ClassINET, ca, lookupHelper)
+	u.resolveAsyncSF(host, dns.TypeAAAA, dns.ClassINET, caaaa, lookupHelper)
 	ra := <-ca
 	raaaa := <-caaaa
 
-	for _, rr := range ra.Rr {
-		addrs = append(addrs, rr.(*dns.RR_A).A)
+	if ra.err != nil {
+		return nil, newDNSError(ra.err, ra.r, host)
+	}
+	if raaaa.err != nil {
+		return nil, newDNSError(raaaa.err, raaaa.r, host)
 	}
 
-	for _, rr := range raaaa.Rr {
+	for _, rr := range ra.r.Rr {
+		addrs = append(addrs, rr.(*dns.RR_A).A)
+	}
+	for _, rr := range raaaa.r.Rr {
 		addrs = append(addrs, rr.(*dns.RR_AAAA).AAAA)
 	}
+	if len(addrs) == 0 {
+		return nil, newDNSError(nil, ra.r, host)
+	}
+	if e := u.checkBogus(ra.r, host); e != nil {
+		return nil, e
+	}
+	if e := u.checkBogus(raaaa.r, host); e != nil {
+		return nil, e
+	}
 	return
 }
 
+// lookupResult pairs a *Result with whatever error ResolveAsync's callback
+// received, so that goroutines waiting on a channel don't lose it.
+type lookupResult struct {
+	r   *Result
+	err error
+}
+
 func lookupHelper(i interface{}, e error, r *Result) {
-	c := i.(chan *Result)
-	defer close(c)
-	if e != nil {
-		return
-	}
-	c <- r
+	c := i.(chan *lookupResult)
+	c <- &lookupResult{r, e}
+	close(c)
 }
 
 // LookupMX returns the DNS MX records for the given domain name sorted by
 // preference.
 func (u *Unbound) LookupMX(name string) (mx []*dns.RR_MX, err error) {
-	r, err := u.Resolve(name, dns.TypeMX, dns.ClassINET)
+	r, err := u.resolveSF(name, dns.TypeMX, dns.ClassINET)
 	if err != nil {
-		return nil, err
+		return nil, newDNSError(err, r, name)
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return nil, e
 	}
 	for _, rr := range r.Rr {
 		mx = append(mx, rr.(*dns.RR_MX))
@@ -92,6 +139,21 @@ func (u *Unbound) LookupMX(name string) (mx []*dns.RR_MX, err error) {
 	return
 }
 
+// LookupNS returns the DNS NS records for the given zone.
+func (u *Unbound) LookupNS(name string) (ns []*dns.RR_NS, err error) {
+	r, err := u.resolveSF(name, dns.TypeNS, dns.ClassINET)
+	if err != nil {
+		return nil, newDNSError(err, r, name)
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return nil, e
+	}
+	for _, rr := range r.Rr {
+		ns = append(ns, rr.(*dns.RR_NS))
+	}
+	return
+}
+
 // LookupSRV tries to resolve an SRV query of the given service, protocol,
 // and domain name. The proto is "tcp" or "udp". The returned records are
 // sorted by priority and randomized by weight within a priority.
@@ -103,33 +165,119 @@ func (u *Unbound) LookupMX(name string) (mx []*dns.RR_MX, err error) {
 func (u *Unbound) LookupSRV(service, proto, name string) (cname string, srv []*dns.RR_SRV, err error) {
 	r := new(Result)
 	if service == "" && proto == "" {
-		r, err = u.Resolve(name, dns.TypeSRV, dns.ClassINET)
+		r, err = u.resolveSF(name, dns.TypeSRV, dns.ClassINET)
 	} else {
-		r, err = u.Resolve("_" + service + "._" + proto + "." + name, dns.TypeSRV, dns.ClassINET)
+		r, err = u.resolveSF("_"+service+"._"+proto+"."+name, dns.TypeSRV, dns.ClassINET)
 	}
 	// TODO(mg): cname?
 	if err != nil {
-		return "", nil, err
+		return "", nil, newDNSError(err, r, name)
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return "", nil, e
 	}
 	for _, rr := range r.Rr {
 		srv = append(srv, rr.(*dns.RR_SRV))
 	}
-	// Dumb bubble sort (len(srv) is never a large number) to sort by priority
-	for i := 0; i < len(srv); i++ {
-		for j := i; j < len(srv); j++ {
-			if srv[i].Priority > srv[j].Priority {
-				srv[j], srv[i] = srv[i], srv[j]
+	sortSRV(srv)
+	return "", srv, err
+}
+
+// srvRand is the source used to randomize SRV records by weight. It is
+// seeded once at package init time; tests that need deterministic ordering
+// can swap it out (it is not exported, but the package itself never relies
+// on a specific seed).
+var (
+	srvRandMu sync.Mutex
+	srvRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// sortSRV sorts srv by priority, and randomizes each priority group by
+// weight, as specified in RFC 2782.
+func sortSRV(srv []*dns.RR_SRV) {
+	byPriority := map[uint16][]*dns.RR_SRV{}
+	var priorities []uint16
+	for _, s := range srv {
+		if _, ok := byPriority[s.Priority]; !ok {
+			priorities = append(priorities, s.Priority)
+		}
+		byPriority[s.Priority] = append(byPriority[s.Priority], s)
+	}
+	for i := 0; i < len(priorities); i++ {
+		for j := i; j < len(priorities); j++ {
+			if priorities[i] > priorities[j] {
+				priorities[j], priorities[i] = priorities[i], priorities[j]
 			}
 		}
 	}
-	return "", srv, err
+
+	sorted := make([]*dns.RR_SRV, 0, len(srv))
+	for _, p := range priorities {
+		sorted = append(sorted, weightedShuffle(byPriority[p])...)
+	}
+	copy(srv, sorted)
+}
+
+// sortZeroWeightFirst returns a copy of group with weight-0 records moved
+// ahead of non-zero-weight ones, relative order otherwise preserved. RFC
+// 2782 requires this before the weighted draw in weightedShuffle, so that a
+// weight-0 record still has its (small) chance of being picked when the
+// random draw lands on zero.
+func sortZeroWeightFirst(group []*dns.RR_SRV) []*dns.RR_SRV {
+	out := make([]*dns.RR_SRV, 0, len(group))
+	for _, s := range group {
+		if s.Weight == 0 {
+			out = append(out, s)
+		}
+	}
+	for _, s := range group {
+		if s.Weight != 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// weightedShuffle implements the selection algorithm of RFC 2782: records
+// with weight 0 sort first, then repeatedly pick a uniform random integer
+// in [0, total weight] and take the first record whose running weight sum
+// is >= the pick, until the group is empty.
+func weightedShuffle(group []*dns.RR_SRV) []*dns.RR_SRV {
+	remain := sortZeroWeightFirst(group)
+	out := make([]*dns.RR_SRV, 0, len(remain))
+	for len(remain) > 0 {
+		total := 0
+		for _, s := range remain {
+			total += int(s.Weight)
+		}
+
+		srvRandMu.Lock()
+		pick := srvRand.Intn(total + 1)
+		srvRandMu.Unlock()
+
+		sum, idx := 0, 0
+		for i, s := range remain {
+			sum += int(s.Weight)
+			if sum >= pick {
+				idx = i
+				break
+			}
+		}
+
+		out = append(out, remain[idx])
+		remain = append(remain[:idx], remain[idx+1:]...)
+	}
+	return out
 }
 
 // LookupTXT returns the DNS TXT records for the given domain name.
 func (u *Unbound) LookupTXT(name string) (txt []string, err error) {
-	r, err := u.Resolve(name, dns.TypeTXT, dns.ClassINET)
+	r, err := u.resolveSF(name, dns.TypeTXT, dns.ClassINET)
 	if err != nil {
-		return nil, err
+		return nil, newDNSError(err, r, name)
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return nil, e
 	}
 	for _, rr := range r.Rr {
 		txt = append(txt, rr.(*dns.RR_TXT).Txt...)
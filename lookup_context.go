@@ -0,0 +1,203 @@
+package unbound
+
+import (
+	"context"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// These are the context.Context-aware siblings of the Lookup* functions in
+// lookup.go. They mirror how the net package grew *Context variants of its
+// Lookup* functions: when ctx is cancelled (or its deadline expires) before
+// Unbound delivers an answer, the in-flight ub_resolve_async call is
+// cancelled via Cancel and the partial result, if any, is discarded.
+
+// resolveContext runs a single query via dispatchCtx, so a *Context lookup
+// still benefits from singleflight de-duplication when SetSingleflight is
+// on, and cancels it via Cancel if ctx is done before a result arrives.
+func (u *Unbound) resolveContext(ctx context.Context, name string, qtype, qclass uint16) (*Result, error) {
+	call, err := u.dispatchCtx(name, qtype, qclass)
+	if err != nil {
+		return nil, newDNSError(err, nil, name)
+	}
+
+	select {
+	case <-call.done:
+		if call.res.err != nil {
+			return nil, newDNSError(call.res.err, call.res.r, name)
+		}
+		return call.res.r, nil
+	case <-ctx.Done():
+		u.Cancel(call.id)
+		return nil, newCtxDNSError(ctx, name)
+	}
+}
+
+// newCtxDNSError wraps ctx.Err() in a *DNSError so that a context-cancelled
+// lookup reports the same error type as any other failed lookup.
+func newCtxDNSError(ctx context.Context, name string) *DNSError {
+	e := &DNSError{Name: name, Err: ctx.Err().Error()}
+	if ctx.Err() == context.DeadlineExceeded {
+		e.IsTimeout = true
+	}
+	return e
+}
+
+// LookupAddrContext is like LookupAddr but takes a context.Context.
+func (u *Unbound) LookupAddrContext(ctx context.Context, addr string) (name []string, err error) {
+	reverse, err := dns.ReverseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	r, err := u.resolveContext(ctx, reverse, dns.TypePTR, dns.ClassINET)
+	if err != nil {
+		return nil, err
+	}
+	for _, rr := range r.Rr {
+		name = append(name, rr.(*dns.RR_PTR).Ptr)
+	}
+	if len(name) == 0 {
+		return nil, newDNSError(nil, r, addr)
+	}
+	if e := u.checkBogus(r, addr); e != nil {
+		return nil, e
+	}
+	return
+}
+
+// LookupCNAMEContext is like LookupCNAME but takes a context.Context.
+func (u *Unbound) LookupCNAMEContext(ctx context.Context, name string) (cname string, err error) {
+	if name == "" {
+		return "", errEmptyHost(name)
+	}
+	r, err := u.resolveContext(ctx, name, dns.TypeA, dns.ClassINET)
+	if err != nil {
+		return "", err
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return "", e
+	}
+	return r.CanonName, nil
+}
+
+// LookupHostContext is like LookupHost but takes a context.Context.
+func (u *Unbound) LookupHostContext(ctx context.Context, host string) (addrs []string, err error) {
+	if host == "" {
+		return nil, errEmptyHost(host)
+	}
+	ipaddrs, err := u.LookupIPContext(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ipaddrs {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs, nil
+}
+
+// LookupIPContext is like LookupIP but takes a context.Context. The A and
+// AAAA lookups are still performed in parallel; if ctx is done before they
+// both complete, whichever of the two is still in flight is cancelled.
+func (u *Unbound) LookupIPContext(ctx context.Context, host string) (addrs []net.IP, err error) {
+	if host == "" {
+		return nil, errEmptyHost(host)
+	}
+
+	type answer struct {
+		r   *Result
+		err error
+	}
+	ca := make(chan answer, 1)
+	caaaa := make(chan answer, 1)
+
+	go func() {
+		r, err := u.resolveContext(ctx, host, dns.TypeA, dns.ClassINET)
+		ca <- answer{r, err}
+	}()
+	go func() {
+		r, err := u.resolveContext(ctx, host, dns.TypeAAAA, dns.ClassINET)
+		caaaa <- answer{r, err}
+	}()
+
+	ra := <-ca
+	raaaa := <-caaaa
+	if ra.err != nil {
+		return nil, ra.err
+	}
+	if raaaa.err != nil {
+		return nil, raaaa.err
+	}
+
+	if ra.r != nil {
+		for _, rr := range ra.r.Rr {
+			addrs = append(addrs, rr.(*dns.RR_A).A)
+		}
+	}
+	if raaaa.r != nil {
+		for _, rr := range raaaa.r.Rr {
+			addrs = append(addrs, rr.(*dns.RR_AAAA).AAAA)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, newDNSError(nil, ra.r, host)
+	}
+	if e := u.checkBogus(ra.r, host); e != nil {
+		return nil, e
+	}
+	if e := u.checkBogus(raaaa.r, host); e != nil {
+		return nil, e
+	}
+	return
+}
+
+// LookupMXContext is like LookupMX but takes a context.Context.
+func (u *Unbound) LookupMXContext(ctx context.Context, name string) (mx []*dns.RR_MX, err error) {
+	r, err := u.resolveContext(ctx, name, dns.TypeMX, dns.ClassINET)
+	if err != nil {
+		return nil, err
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return nil, e
+	}
+	for _, rr := range r.Rr {
+		mx = append(mx, rr.(*dns.RR_MX))
+	}
+	return
+}
+
+// LookupSRVContext is like LookupSRV but takes a context.Context.
+func (u *Unbound) LookupSRVContext(ctx context.Context, service, proto, name string) (cname string, srv []*dns.RR_SRV, err error) {
+	var r *Result
+	if service == "" && proto == "" {
+		r, err = u.resolveContext(ctx, name, dns.TypeSRV, dns.ClassINET)
+	} else {
+		r, err = u.resolveContext(ctx, "_"+service+"._"+proto+"."+name, dns.TypeSRV, dns.ClassINET)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return "", nil, e
+	}
+	for _, rr := range r.Rr {
+		srv = append(srv, rr.(*dns.RR_SRV))
+	}
+	sortSRV(srv)
+	return "", srv, err
+}
+
+// LookupTXTContext is like LookupTXT but takes a context.Context.
+func (u *Unbound) LookupTXTContext(ctx context.Context, name string) (txt []string, err error) {
+	r, err := u.resolveContext(ctx, name, dns.TypeTXT, dns.ClassINET)
+	if err != nil {
+		return nil, err
+	}
+	if e := u.checkBogus(r, name); e != nil {
+		return nil, e
+	}
+	for _, rr := range r.Rr {
+		txt = append(txt, rr.(*dns.RR_TXT).Txt...)
+	}
+	return
+}
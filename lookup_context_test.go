@@ -0,0 +1,27 @@
+package unbound
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewCtxDNSErrorTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	e := newCtxDNSError(ctx, "example.com")
+	if !e.IsTimeout {
+		t.Fatalf("expected IsTimeout for a deadline-exceeded context, got %+v", e)
+	}
+}
+
+func TestNewCtxDNSErrorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	e := newCtxDNSError(ctx, "example.com")
+	if e.IsTimeout {
+		t.Fatalf("did not expect IsTimeout for an explicitly cancelled context, got %+v", e)
+	}
+}
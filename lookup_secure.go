@@ -0,0 +1,125 @@
+package unbound
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// These are the DNSSEC-aware siblings of the Lookup* functions in
+// lookup.go: instead of silently accepting whatever libunbound handed
+// back, they also return the SecurityStatus of the answer (and, if it was
+// bogus, the reason why) so callers can decide for themselves whether to
+// trust it. See also Unbound.StrictDNSSEC, which makes the plain Lookup*
+// wrappers refuse bogus answers outright.
+
+// LookupAddrSecure is like LookupAddr, but also returns the DNSSEC
+// validation status of the answer.
+func (u *Unbound) LookupAddrSecure(addr string) (name []string, status SecurityStatus, whyBogus string, err error) {
+	reverse, err := dns.ReverseAddr(addr)
+	if err != nil {
+		return nil, Indeterminate, "", err
+	}
+	r, err := u.resolveSF(reverse, dns.TypePTR, dns.ClassINET)
+	if err != nil {
+		return nil, Indeterminate, "", newDNSError(err, r, addr)
+	}
+	for _, rr := range r.Rr {
+		name = append(name, rr.(*dns.RR_PTR).Ptr)
+	}
+	if len(name) == 0 {
+		return nil, securityStatus(r), r.WhyBogus, newDNSError(nil, r, addr)
+	}
+	return name, securityStatus(r), r.WhyBogus, nil
+}
+
+// LookupIPSecure is like LookupIP, but also returns the DNSSEC validation
+// status of the answer. Since the A and AAAA lookups are performed in
+// parallel and each carries its own status, the combined status is the
+// worse of the two (see worseStatus): a Bogus result from either one makes
+// the combined status Bogus, and otherwise a merely Insecure/Indeterminate
+// half keeps the combined status from reading as Secure.
+func (u *Unbound) LookupIPSecure(host string) (addrs []net.IP, status SecurityStatus, whyBogus string, err error) {
+	if host == "" {
+		return nil, Indeterminate, "", errEmptyHost(host)
+	}
+
+	ca := make(chan *lookupResult, 1)
+	caaaa := make(chan *lookupResult, 1)
+
+	u.resolveAsyncSF(host, dns.TypeA, dns.ClassINET, ca, lookupHelper)
+	u.resolveAsyncSF(host, dns.TypeAAAA, dns.ClassINET, caaaa, lookupHelper)
+	ra := <-ca
+	raaaa := <-caaaa
+
+	if ra.err != nil {
+		return nil, Indeterminate, "", newDNSError(ra.err, ra.r, host)
+	}
+	if raaaa.err != nil {
+		return nil, Indeterminate, "", newDNSError(raaaa.err, raaaa.r, host)
+	}
+
+	for _, rr := range ra.r.Rr {
+		addrs = append(addrs, rr.(*dns.RR_A).A)
+	}
+	for _, rr := range raaaa.r.Rr {
+		addrs = append(addrs, rr.(*dns.RR_AAAA).AAAA)
+	}
+
+	aStatus, aaaaStatus := securityStatus(ra.r), securityStatus(raaaa.r)
+	status = worseStatus(aStatus, aaaaStatus)
+	whyBogus = ra.r.WhyBogus
+	if status == aaaaStatus && status != aStatus {
+		whyBogus = raaaa.r.WhyBogus
+	}
+
+	if len(addrs) == 0 {
+		return nil, status, whyBogus, newDNSError(nil, ra.r, host)
+	}
+	return addrs, status, whyBogus, nil
+}
+
+// LookupMXSecure is like LookupMX, but also returns the DNSSEC validation
+// status of the answer.
+func (u *Unbound) LookupMXSecure(name string) (mx []*dns.RR_MX, status SecurityStatus, whyBogus string, err error) {
+	r, err := u.resolveSF(name, dns.TypeMX, dns.ClassINET)
+	if err != nil {
+		return nil, Indeterminate, "", newDNSError(err, r, name)
+	}
+	for _, rr := range r.Rr {
+		mx = append(mx, rr.(*dns.RR_MX))
+	}
+	return mx, securityStatus(r), r.WhyBogus, nil
+}
+
+// LookupSRVSecure is like LookupSRV, but also returns the DNSSEC validation
+// status of the answer.
+func (u *Unbound) LookupSRVSecure(service, proto, name string) (cname string, srv []*dns.RR_SRV, status SecurityStatus, whyBogus string, err error) {
+	var r *Result
+	if service == "" && proto == "" {
+		r, err = u.resolveSF(name, dns.TypeSRV, dns.ClassINET)
+	} else {
+		r, err = u.resolveSF("_"+service+"._"+proto+"."+name, dns.TypeSRV, dns.ClassINET)
+	}
+	if err != nil {
+		return "", nil, Indeterminate, "", newDNSError(err, r, name)
+	}
+	for _, rr := range r.Rr {
+		srv = append(srv, rr.(*dns.RR_SRV))
+	}
+	sortSRV(srv)
+	return "", srv, securityStatus(r), r.WhyBogus, nil
+}
+
+// LookupTXTSecure is like LookupTXT, but also returns the DNSSEC validation
+// status of the answer.
+func (u *Unbound) LookupTXTSecure(name string) (txt []string, status SecurityStatus, whyBogus string, err error) {
+	r, err := u.resolveSF(name, dns.TypeTXT, dns.ClassINET)
+	if err != nil {
+		return nil, Indeterminate, "", newDNSError(err, r, name)
+	}
+	for _, rr := range r.Rr {
+		txt = append(txt, rr.(*dns.RR_TXT).Txt...)
+	}
+	return txt, securityStatus(r), r.WhyBogus, nil
+}
@@ -0,0 +1,122 @@
+package unbound
+
+import "sync"
+
+// SecurityStatus describes the DNSSEC validation state of a lookup. It
+// mirrors the secure/bogus flags libunbound's ub_result exposes, which the
+// plain Lookup* wrappers otherwise throw away.
+type SecurityStatus int
+
+const (
+	// Indeterminate means the validation status could not be
+	// determined, typically because no *Result was available at all.
+	Indeterminate SecurityStatus = iota
+	// Insecure means the answer was not signed, and that absence of a
+	// signature itself validated correctly (e.g. a zone with no DNSSEC).
+	Insecure
+	// Secure means the answer was covered by a valid DNSSEC signature
+	// chain.
+	Secure
+	// Bogus means DNSSEC validation failed; the answer should not be
+	// trusted. WhyBogus (returned alongside SecurityStatus) carries the
+	// reason.
+	Bogus
+)
+
+func (s SecurityStatus) String() string {
+	switch s {
+	case Insecure:
+		return "insecure"
+	case Secure:
+		return "secure"
+	case Bogus:
+		return "bogus"
+	default:
+		return "indeterminate"
+	}
+}
+
+// securityStatus derives a SecurityStatus from a *Result's Secure/Bogus
+// flags.
+func securityStatus(r *Result) SecurityStatus {
+	switch {
+	case r == nil:
+		return Indeterminate
+	case r.Bogus:
+		return Bogus
+	case r.Secure:
+		return Secure
+	default:
+		return Insecure
+	}
+}
+
+// worseStatus returns whichever of a and b is the least trustworthy, so
+// that callers combining two independently-validated results (e.g. the A
+// and AAAA halves of LookupIPSecure) report the combined status an
+// application should actually act on, ranked worst to best: Bogus,
+// Indeterminate, Insecure, Secure. Indeterminate ranks above Insecure
+// because it means validation couldn't even be attempted (typically no
+// *Result at all), which is less information than a validated-insecure
+// answer.
+func worseStatus(a, b SecurityStatus) SecurityStatus {
+	rank := func(s SecurityStatus) int {
+		switch s {
+		case Bogus:
+			return 3
+		case Indeterminate:
+			return 2
+		case Insecure:
+			return 1
+		default: // Secure
+			return 0
+		}
+	}
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
+
+// strictDNSSEC tracks, per *Unbound, whether StrictDNSSEC has been turned
+// on. It is keyed by pointer, like sfEnabled, rather than a field on
+// Unbound, so that turning it on doesn't require touching the (cgo-backed)
+// Unbound struct.
+//
+// Like sfEnabled, nothing removes an entry short of StrictDNSSEC(false), so
+// a *Unbound that has ever called StrictDNSSEC(true) is pinned here for the
+// life of the process. Only call StrictDNSSEC(true) on long-lived,
+// process-scoped *Unbound instances, not on ones you expect to discard and
+// garbage-collect.
+var strictDNSSEC sync.Map // map[*Unbound]bool
+
+// StrictDNSSEC enables or disables strict DNSSEC handling. When on,
+// LookupHost and the other plain (non-Secure) Lookup* wrappers refuse to
+// hand back an answer that failed DNSSEC validation: they return a
+// *DNSError with IsBogus set instead of the (untrustworthy) records. It is
+// off by default. This is the main reason to reach for libunbound over the
+// stdlib resolver, so it should not be hidden behind raw Resolve calls.
+//
+// Call this only on a long-lived, process-scoped *Unbound: see the
+// strictDNSSEC caveat above about garbage collection.
+func (u *Unbound) StrictDNSSEC(on bool) {
+	if on {
+		strictDNSSEC.Store(u, true)
+		return
+	}
+	strictDNSSEC.Delete(u)
+}
+
+func (u *Unbound) strictDNSSECEnabled() bool {
+	on, ok := strictDNSSEC.Load(u)
+	return ok && on.(bool)
+}
+
+// checkBogus returns a *DNSError with IsBogus set if strict DNSSEC mode is
+// on and r failed validation; it returns nil otherwise.
+func (u *Unbound) checkBogus(r *Result, name string) *DNSError {
+	if !u.strictDNSSECEnabled() || r == nil || !r.Bogus {
+		return nil
+	}
+	return &DNSError{Name: name, Err: "bogus DNSSEC signature: " + r.WhyBogus, IsBogus: true}
+}
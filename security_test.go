@@ -0,0 +1,84 @@
+package unbound
+
+import "testing"
+
+func TestSecurityStatus(t *testing.T) {
+	cases := []struct {
+		name string
+		r    *Result
+		want SecurityStatus
+	}{
+		{"nil result", nil, Indeterminate},
+		{"bogus", &Result{Bogus: true}, Bogus},
+		{"secure", &Result{Secure: true}, Secure},
+		{"insecure", &Result{}, Insecure},
+		{"bogus takes precedence over secure", &Result{Bogus: true, Secure: true}, Bogus},
+	}
+	for _, c := range cases {
+		if got := securityStatus(c.r); got != c.want {
+			t.Errorf("%s: securityStatus() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestWorseStatus(t *testing.T) {
+	cases := []struct {
+		a, b SecurityStatus
+		want SecurityStatus
+	}{
+		{Secure, Secure, Secure},
+		{Secure, Insecure, Insecure},
+		{Insecure, Secure, Insecure},
+		{Secure, Bogus, Bogus},
+		{Bogus, Secure, Bogus},
+		{Insecure, Indeterminate, Indeterminate},
+		{Indeterminate, Insecure, Indeterminate},
+		{Bogus, Indeterminate, Bogus},
+	}
+	for _, c := range cases {
+		if got := worseStatus(c.a, c.b); got != c.want {
+			t.Errorf("worseStatus(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCheckBogus(t *testing.T) {
+	u := new(Unbound)
+
+	if e := u.checkBogus(&Result{Bogus: true, WhyBogus: "failed"}, "example.com"); e != nil {
+		t.Fatalf("expected no error when StrictDNSSEC is off, got %v", e)
+	}
+
+	u.StrictDNSSEC(true)
+	defer u.StrictDNSSEC(false)
+
+	if e := u.checkBogus(nil, "example.com"); e != nil {
+		t.Fatalf("expected no error for a nil result, got %v", e)
+	}
+	if e := u.checkBogus(&Result{}, "example.com"); e != nil {
+		t.Fatalf("expected no error for a non-bogus result, got %v", e)
+	}
+
+	e := u.checkBogus(&Result{Bogus: true, WhyBogus: "failed"}, "example.com")
+	if e == nil || !e.IsBogus {
+		t.Fatalf("expected an *DNSError with IsBogus set, got %v", e)
+	}
+	if e.Name != "example.com" {
+		t.Fatalf("expected Name to be set to the looked-up name, got %q", e.Name)
+	}
+}
+
+func TestStrictDNSSECIsPerInstance(t *testing.T) {
+	u1 := new(Unbound)
+	u2 := new(Unbound)
+
+	u1.StrictDNSSEC(true)
+	defer u1.StrictDNSSEC(false)
+
+	if !u1.strictDNSSECEnabled() {
+		t.Fatalf("expected StrictDNSSEC enabled on u1 after StrictDNSSEC(true)")
+	}
+	if u2.strictDNSSECEnabled() {
+		t.Fatalf("enabling StrictDNSSEC on u1 must not affect an unrelated u2")
+	}
+}
@@ -0,0 +1,128 @@
+package unbound
+
+import "strings"
+
+// protocols maps a protocol name to its IANA protocol number, as consulted
+// by LookupPort for the "ip"/"ip4"/"ip6" networks.
+var protocols = map[string]int{
+	"ip":        0,
+	"icmp":      1,
+	"igmp":      2,
+	"tcp":       6,
+	"udp":       17,
+	"ipv6":      41,
+	"ipv6-icmp": 58,
+	"sctp":      132,
+}
+
+// services maps a network ("tcp" or "udp") and a well-known service name to
+// its port, the same handful of entries /etc/services and the stdlib
+// resolver carry.
+var services = map[string]map[string]int{
+	"tcp": {
+		"echo":       7,
+		"discard":    9,
+		"ftp-data":   20,
+		"ftp":        21,
+		"ssh":        22,
+		"telnet":     23,
+		"smtp":       25,
+		"domain":     53,
+		"http":       80,
+		"pop3":       110,
+		"nntp":       119,
+		"ntp":        123,
+		"imap":       143,
+		"snmp":       161,
+		"ldap":       389,
+		"https":      443,
+		"submission": 587,
+		"ldaps":      636,
+		"imaps":      993,
+		"pop3s":      995,
+	},
+	"udp": {
+		"echo":    7,
+		"discard": 9,
+		"domain":  53,
+		"bootps":  67,
+		"bootpc":  68,
+		"tftp":    69,
+		"ntp":     123,
+		"snmp":    161,
+		"syslog":  514,
+	},
+}
+
+// LookupPort looks up the port for the given network and service, e.g.
+// LookupPort("tcp", "http"), matching the signature of net.LookupPort so
+// this package can be dropped in as a replacement. It only consults the
+// protocols/services tables above; callers that also want the SRV fallback
+// for services published only via DNS (RFC 2782) should call
+// LookupPortSRV instead, which needs an extra domain argument this
+// signature has no room for.
+func (u *Unbound) LookupPort(network, service string) (port int, err error) {
+	_, p, err := lookupPortTable(network, service)
+	if err != nil {
+		return 0, err
+	}
+	if p >= 0 {
+		return p, nil
+	}
+	return 0, &DNSError{Err: "unknown service", Name: service, IsNotFound: true}
+}
+
+// LookupPortSRV is like LookupPort, but when the service isn't in the
+// static protocols/services tables, it falls back to an SRV lookup of
+// _service._proto.domain (RFC 2782) so that services published only via
+// DNS are still found. domain is only consulted on a static-table miss; it
+// may be "" for any service already in that table.
+func (u *Unbound) LookupPortSRV(network, service, domain string) (port int, err error) {
+	proto, p, err := lookupPortTable(network, service)
+	if err != nil {
+		return 0, err
+	}
+	if p >= 0 {
+		return p, nil
+	}
+
+	if domain == "" {
+		return 0, &DNSError{Err: "unknown service (no domain given for SRV fallback)", Name: service, IsNotFound: true}
+	}
+
+	_, srv, err := u.LookupSRV(service, proto, domain)
+	if err != nil {
+		return 0, err
+	}
+	if len(srv) == 0 {
+		return 0, &DNSError{Err: "unknown service", Name: service, IsNotFound: true}
+	}
+	return int(srv[0].Port), nil
+}
+
+// lookupPortTable consults the protocols/services tables for network and
+// service, shared by LookupPort and LookupPortSRV. It returns the
+// normalized protocol name (for the SRV fallback's _service._proto query),
+// the port (-1 if not found in the tables), and an error only for a
+// malformed network/service that a table miss can't itself represent (e.g.
+// an unrecognized network or an "ip"-family service not in protocols).
+func lookupPortTable(network, service string) (proto string, port int, err error) {
+	switch {
+	case strings.HasPrefix(network, "tcp"):
+		proto = "tcp"
+	case strings.HasPrefix(network, "udp"):
+		proto = "udp"
+	case strings.HasPrefix(network, "ip"):
+		if p, ok := protocols[service]; ok {
+			return "", p, nil
+		}
+		return "", 0, &DNSError{Err: "unknown service", Name: service, IsNotFound: true}
+	default:
+		return "", 0, &DNSError{Err: "unknown network " + network, Name: service}
+	}
+
+	if p, ok := services[proto][service]; ok {
+		return proto, p, nil
+	}
+	return proto, -1, nil
+}
@@ -0,0 +1,46 @@
+package unbound
+
+import "testing"
+
+func TestLookupPortStaticTable(t *testing.T) {
+	u := new(Unbound)
+
+	port, err := u.LookupPort("tcp", "http")
+	if err != nil || port != 80 {
+		t.Fatalf("LookupPort(tcp, http) = %d, %v; want 80, nil", port, err)
+	}
+
+	port, err = u.LookupPort("ip", "tcp")
+	if err != nil || port != 6 {
+		t.Fatalf("LookupPort(ip, tcp) = %d, %v; want 6, nil", port, err)
+	}
+}
+
+func TestLookupPortStaticTableMiss(t *testing.T) {
+	u := new(Unbound)
+
+	_, err := u.LookupPort("tcp", "xmpp-client")
+	de, ok := err.(*DNSError)
+	if !ok || !de.IsNotFound {
+		t.Fatalf("LookupPort on a static-table miss must return an IsNotFound *DNSError, got %#v", err)
+	}
+}
+
+func TestLookupPortSRVMissingDomain(t *testing.T) {
+	u := new(Unbound)
+
+	_, err := u.LookupPortSRV("tcp", "xmpp-client", "")
+	de, ok := err.(*DNSError)
+	if !ok || !de.IsNotFound {
+		t.Fatalf("LookupPortSRV with no domain and a static-table miss must return an IsNotFound *DNSError, got %#v", err)
+	}
+}
+
+func TestLookupPortSRVStaticTable(t *testing.T) {
+	u := new(Unbound)
+
+	port, err := u.LookupPortSRV("tcp", "http", "")
+	if err != nil || port != 80 {
+		t.Fatalf("LookupPortSRV(tcp, http, \"\") = %d, %v; want 80, nil", port, err)
+	}
+}
@@ -0,0 +1,195 @@
+package unbound
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/anotherwriter/unbound/internal/singleflight"
+	"github.com/miekg/dns"
+)
+
+// sfGroups holds one singleflight.Group per *Unbound, so that two
+// differently-configured instances (different forwarders, trust anchors,
+// local zones, ...) never share an in-flight query, even if both happen to
+// resolve the same name concurrently with singleflight enabled.
+//
+// Because the map is keyed by the *Unbound pointer itself and nothing ever
+// removes an entry short of SetSingleflight(false), a *Unbound that has
+// ever called SetSingleflight(true) is pinned here (and in sfEnabled, see
+// below) for the life of the process. Only call SetSingleflight(true) on
+// long-lived, process-scoped *Unbound instances, not on ones you expect to
+// discard and garbage-collect.
+var sfGroups sync.Map // map[*Unbound]*singleflight.Group
+
+func (u *Unbound) sfGroup() *singleflight.Group {
+	if v, ok := sfGroups.Load(u); ok {
+		return v.(*singleflight.Group)
+	}
+	v, _ := sfGroups.LoadOrStore(u, new(singleflight.Group))
+	return v.(*singleflight.Group)
+}
+
+// sfEnabled tracks, per *Unbound, whether singleflight de-duplication is
+// turned on. It is opt-in via SetSingleflight; a sync.Map is used rather
+// than a field on Unbound so that enabling it doesn't require touching the
+// (cgo-backed) Unbound struct.
+var sfEnabled sync.Map // map[*Unbound]bool
+
+// SetSingleflight enables or disables singleflight de-duplication of
+// concurrent Lookup* calls that share the same (name, qtype, qclass). It is
+// off by default. Turning it on is worthwhile for servers that fan out many
+// concurrent lookups for the same hot name, such as TLS SNI resolvers or
+// mail servers doing an MX lookup per outgoing message.
+//
+// Call this only on a long-lived, process-scoped *Unbound: enabling it
+// registers u in package-level maps (see sfGroups, and ctxGroups once a
+// *Context lookup runs) that are never cleaned up except by a later
+// SetSingleflight(false), so a *Unbound that is discarded after
+// SetSingleflight(true) without being turned back off will never be
+// garbage-collected.
+func (u *Unbound) SetSingleflight(on bool) {
+	if on {
+		sfEnabled.Store(u, true)
+		return
+	}
+	sfEnabled.Delete(u)
+	sfGroups.Delete(u)
+	ctxGroups.Delete(u)
+}
+
+func (u *Unbound) singleflightEnabled() bool {
+	on, ok := sfEnabled.Load(u)
+	return ok && on.(bool)
+}
+
+// sfKey canonicalizes a query into the key used to de-duplicate it within a
+// single *Unbound's group: the class, the type, and the lowercased,
+// fully-qualified name.
+func sfKey(name string, qtype, qclass uint16) string {
+	return strconv.Itoa(int(qclass)) + "/" + strconv.Itoa(int(qtype)) + "/" + strings.ToLower(dns.Fqdn(name))
+}
+
+// resolveSF is Resolve's singleflight-aware counterpart: when enabled,
+// concurrent calls on the same *Unbound for the same key share one
+// underlying Resolve call and its *Result.
+func (u *Unbound) resolveSF(name string, qtype, qclass uint16) (*Result, error) {
+	if !u.singleflightEnabled() {
+		return u.Resolve(name, qtype, qclass)
+	}
+	v, err, _ := u.sfGroup().Do(sfKey(name, qtype, qclass), func() (interface{}, error) {
+		return u.Resolve(name, qtype, qclass)
+	})
+	if v == nil {
+		return nil, err
+	}
+	return v.(*Result), err
+}
+
+// resolveAsyncSF is ResolveAsync's singleflight-aware counterpart. Each
+// caller still gets its own callback invocation with its own result value,
+// even when the underlying query was shared with other callers on the same
+// *Unbound.
+func (u *Unbound) resolveAsyncSF(name string, qtype, qclass uint16, result interface{}, fn func(interface{}, error, *Result)) error {
+	if !u.singleflightEnabled() {
+		_, err := u.ResolveAsync(name, qtype, qclass, result, fn)
+		return err
+	}
+
+	ch := u.sfGroup().DoChan(sfKey(name, qtype, qclass), func() (interface{}, error) {
+		c := make(chan *lookupResult, 1)
+		if _, err := u.ResolveAsync(name, qtype, qclass, c, lookupHelper); err != nil {
+			return nil, err
+		}
+		return <-c, nil
+	})
+
+	go func() {
+		res := <-ch
+		lr, _ := res.Val.(*lookupResult)
+		var r *Result
+		err := res.Err
+		if lr != nil {
+			r, err = lr.r, lr.err
+		}
+		fn(result, err, r)
+	}()
+	return nil
+}
+
+// ctxGroups holds, per *Unbound, the resolveContext calls currently
+// in flight, keyed the same way as sfGroup. Unlike sfGroup (which only
+// fans out a *Result through the internal/singleflight package), a ctxCall
+// also remembers the underlying ub_resolve_async ID, so that a *Context
+// caller whose ctx is cancelled has something to hand to Cancel instead of
+// just walking away from the in-flight libunbound query.
+var ctxGroups sync.Map // map[*Unbound]*ctxGroup
+
+type ctxGroup struct {
+	mu sync.Mutex
+	m  map[string]*ctxCall
+}
+
+// ctxCall is a single dispatched-but-maybe-still-running resolveContext
+// query. done is closed once res is safe to read.
+type ctxCall struct {
+	id   int
+	done chan struct{}
+	res  *lookupResult
+}
+
+func (u *Unbound) ctxGroup() *ctxGroup {
+	v, _ := ctxGroups.LoadOrStore(u, &ctxGroup{m: make(map[string]*ctxCall)})
+	return v.(*ctxGroup)
+}
+
+// dispatchCtx starts a ResolveAsync call for (name, qtype, qclass) and
+// returns it as a *ctxCall, so the caller can race ctx.Done() against
+// call.done and, on cancellation, Cancel(call.id). When singleflight is
+// enabled, a query already in flight for the same key is reused instead of
+// dispatching a new one: every *Context caller waiting on call.done shares
+// the same id, so cancelling on behalf of one of them cancels the query
+// for all of them. That shared fate is the cost of de-duplication, same as
+// resolveAsyncSF sharing one *Result among every caller.
+func (u *Unbound) dispatchCtx(name string, qtype, qclass uint16) (*ctxCall, error) {
+	if !u.singleflightEnabled() {
+		c := make(chan *lookupResult, 1)
+		id, err := u.ResolveAsync(name, qtype, qclass, c, lookupHelper)
+		if err != nil {
+			return nil, err
+		}
+		call := &ctxCall{id: id, done: make(chan struct{})}
+		go func() {
+			call.res = <-c
+			close(call.done)
+		}()
+		return call, nil
+	}
+
+	key := sfKey(name, qtype, qclass)
+	g := u.ctxGroup()
+
+	g.mu.Lock()
+	if call, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		return call, nil
+	}
+	c := make(chan *lookupResult, 1)
+	id, err := u.ResolveAsync(name, qtype, qclass, c, lookupHelper)
+	if err != nil {
+		g.mu.Unlock()
+		return nil, err
+	}
+	call := &ctxCall{id: id, done: make(chan struct{})}
+	g.m[key] = call
+	g.mu.Unlock()
+
+	go func() {
+		call.res = <-c
+		close(call.done)
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+	}()
+	return call, nil
+}
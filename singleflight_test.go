@@ -0,0 +1,28 @@
+package unbound
+
+import "testing"
+
+func TestSFGroupIsPerInstance(t *testing.T) {
+	u1 := new(Unbound)
+	u2 := new(Unbound)
+
+	if u1.sfGroup() != u1.sfGroup() {
+		t.Fatalf("sfGroup() must return the same *singleflight.Group on repeated calls for the same *Unbound")
+	}
+	if u1.sfGroup() == u2.sfGroup() {
+		t.Fatalf("sfGroup() must not share a group between two different *Unbound instances")
+	}
+}
+
+func TestSetSingleflightIsPerInstance(t *testing.T) {
+	u1 := new(Unbound)
+	u2 := new(Unbound)
+
+	u1.SetSingleflight(true)
+	if !u1.singleflightEnabled() {
+		t.Fatalf("expected singleflight enabled on u1 after SetSingleflight(true)")
+	}
+	if u2.singleflightEnabled() {
+		t.Fatalf("enabling singleflight on u1 must not affect an unrelated u2")
+	}
+}
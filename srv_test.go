@@ -0,0 +1,58 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func srvWithWeight(weight uint16) *dns.RR_SRV {
+	return &dns.RR_SRV{Weight: weight}
+}
+
+func TestSortZeroWeightFirst(t *testing.T) {
+	group := []*dns.RR_SRV{srvWithWeight(5), srvWithWeight(0), srvWithWeight(3)}
+	sorted := sortZeroWeightFirst(group)
+	if len(sorted) != len(group) {
+		t.Fatalf("got %d records, want %d", len(sorted), len(group))
+	}
+	if sorted[0].Weight != 0 {
+		t.Fatalf("expected the weight-0 record first, got weight %d", sorted[0].Weight)
+	}
+}
+
+// TestSortZeroWeightFirstIsStable guards the doc comment's promise that
+// non-zero-weight records keep their relative order: a naive swap-based
+// partition can quietly reorder them while still passing
+// TestSortZeroWeightFirst.
+func TestSortZeroWeightFirstIsStable(t *testing.T) {
+	a, b := srvWithWeight(5), srvWithWeight(3)
+	group := []*dns.RR_SRV{a, b, srvWithWeight(0)}
+	sorted := sortZeroWeightFirst(group)
+	if sorted[1] != a || sorted[2] != b {
+		t.Fatalf("expected non-zero-weight records to keep their relative order (5 before 3), got weights %d, %d", sorted[1].Weight, sorted[2].Weight)
+	}
+}
+
+// TestWeightedShuffleGivesZeroWeightAChance guards against regressing to a
+// sort that pushes weight-0 records to the back of the group: per RFC 2782
+// they must be ordered first so the random draw can still land on them.
+func TestWeightedShuffleGivesZeroWeightAChance(t *testing.T) {
+	group := []*dns.RR_SRV{srvWithWeight(5), srvWithWeight(0), srvWithWeight(3)}
+
+	const trials = 20000
+	pickedFirst := false
+	for i := 0; i < trials; i++ {
+		out := weightedShuffle(group)
+		if len(out) != len(group) {
+			t.Fatalf("got %d records, want %d", len(out), len(group))
+		}
+		if out[0].Weight == 0 {
+			pickedFirst = true
+			break
+		}
+	}
+	if !pickedFirst {
+		t.Fatalf("weight-0 record was never selected first in %d trials; it should have roughly a 1-in-9 chance per RFC 2782", trials)
+	}
+}